@@ -0,0 +1,101 @@
+// Package dialect описывает именованные CSV-диалекты (разделитель, BOM,
+// перевод строк), общие для экстрактора цитат и random-quote, чтобы файлы,
+// записанные одним инструментом, всегда корректно читались другим.
+package dialect
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// Dialect описывает параметры чтения/записи CSV.
+type Dialect struct {
+	Name    string
+	Comma   rune
+	BOM     bool
+	UseCRLF bool
+}
+
+var (
+	// ExcelRU — разделитель ";", BOM для Excel, CRLF. Исторический формат
+	// этого репозитория.
+	ExcelRU = Dialect{Name: "excel-ru", Comma: ';', BOM: true, UseCRLF: true}
+	// RFC4180 — стандартный CSV: запятая, без BOM, LF.
+	RFC4180 = Dialect{Name: "rfc4180", Comma: ',', BOM: false, UseCRLF: false}
+	// TSV — табуляция в качестве разделителя, без BOM, LF.
+	TSV = Dialect{Name: "tsv", Comma: '\t', BOM: false, UseCRLF: false}
+)
+
+// byName перечисляет диалекты, доступные через --csv-dialect.
+var byName = map[string]Dialect{
+	ExcelRU.Name: ExcelRU,
+	RFC4180.Name: RFC4180,
+	TSV.Name:     TSV,
+}
+
+// Parse возвращает диалект по его имени (значение флага --csv-dialect).
+func Parse(name string) (Dialect, error) {
+	d, ok := byName[name]
+	if !ok {
+		return Dialect{}, fmt.Errorf("неизвестный csv-dialect %q (доступны: excel-ru, rfc4180, tsv)", name)
+	}
+	return d, nil
+}
+
+// NewWriter создаёт *csv.Writer, настроенный под диалект, и при необходимости
+// пишет BOM в начало потока.
+func (d Dialect) NewWriter(w io.Writer) (*csv.Writer, error) {
+	if d.BOM {
+		if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return nil, err
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = d.Comma
+	cw.UseCRLF = d.UseCRLF
+	return cw, nil
+}
+
+// Detect определяет диалект файла по BOM и по разделителю в первой строке,
+// чтобы random-quote мог читать CSV независимо от того, каким инструментом
+// (или с каким --csv-dialect) он был создан.
+func Detect(r *bufio.Reader) (Dialect, error) {
+	hasBOM := false
+	bom, err := r.Peek(3)
+	if err == nil && len(bom) == 3 && bom[0] == 0xEF && bom[1] == 0xBB && bom[2] == 0xBF {
+		hasBOM = true
+		if _, err := r.Discard(3); err != nil {
+			return Dialect{}, err
+		}
+	}
+
+	line, err := r.Peek(4096)
+	if err != nil && err != io.EOF {
+		return Dialect{}, err
+	}
+
+	comma := RFC4180.Comma
+	switch {
+	case countRune(line, '\t') > countRune(line, ';') && countRune(line, '\t') > countRune(line, ','):
+		comma = TSV.Comma
+	case countRune(line, ';') > countRune(line, ','):
+		comma = ExcelRU.Comma
+	}
+
+	return Dialect{Name: "detected", Comma: comma, BOM: hasBOM}, nil
+}
+
+func countRune(b []byte, r byte) int {
+	n := 0
+	for _, c := range b {
+		if c == r {
+			n++
+		}
+		if c == '\n' {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,225 @@
+// Package essink индексирует извлечённые цитаты в OpenSearch/Elasticsearch,
+// превращая разрозненные CSV/JSONL в единый, доступный для поиска корпус.
+package essink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+)
+
+// Config — параметры подключения, собираемые из переменных окружения.
+type Config struct {
+	URL         string
+	IndexPrefix string
+	User        string
+	Password    string
+	SkipVerify  bool
+}
+
+// ConfigFromEnv читает ELASTICSEARCH_URL, ES_INDEX_PREFIX, ES_USER,
+// ES_PASSWORD и ES_SKIP_VERIFY.
+func ConfigFromEnv() Config {
+	skipVerify, _ := strconv.ParseBool(os.Getenv("ES_SKIP_VERIFY"))
+	prefix := os.Getenv("ES_INDEX_PREFIX")
+	if prefix == "" {
+		prefix = "quotes"
+	}
+	return Config{
+		URL:         os.Getenv("ELASTICSEARCH_URL"),
+		IndexPrefix: prefix,
+		User:        os.Getenv("ES_USER"),
+		Password:    os.Getenv("ES_PASSWORD"),
+		SkipVerify:  skipVerify,
+	}
+}
+
+// Doc — документ, индексируемый в OpenSearch; соответствует мэппингу,
+// создаваемому EnsureIndex.
+type Doc struct {
+	Text          string    `json:"text"`
+	Author        string    `json:"author"`
+	ContextBefore string    `json:"context_before"`
+	ContextAfter  string    `json:"context_after"`
+	SourceFile    string    `json:"source_file,omitempty"`
+	Timestamp     time.Time `json:"@timestamp"`
+}
+
+// Sink — батчевый индексатор: принимает документы через Submit и раз в
+// flushSize штук либо flushEvery сбрасывает их в OpenSearch через Bulk API.
+// Воркеры запускаются в собственных горутинах, поэтому индексация идёт
+// параллельно с вызовами LLM в processBlock.
+type Sink struct {
+	client      *opensearch.Client
+	indexPrefix string
+	flushSize   int
+	flushEvery  time.Duration
+	queue       chan Doc
+	wg          sync.WaitGroup
+}
+
+// New создаёт Sink и запускает workers фоновых воркеров-флашеров.
+func New(cfg Config, workers int) (*Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("ELASTICSEARCH_URL не задан")
+	}
+
+	osCfg := opensearch.Config{
+		Addresses: strings.Split(cfg.URL, ","),
+		Username:  cfg.User,
+		Password:  cfg.Password,
+	}
+	if cfg.SkipVerify {
+		osCfg.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+
+	client, err := opensearch.NewClient(osCfg)
+	if err != nil {
+		return nil, fmt.Errorf("создание клиента OpenSearch: %w", err)
+	}
+
+	s := &Sink{
+		client:      client,
+		indexPrefix: cfg.IndexPrefix,
+		flushSize:   500,
+		flushEvery:  5 * time.Second,
+		queue:       make(chan Doc, 1000),
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	return s, nil
+}
+
+// indexName возвращает имя индекса вида quotes-YYYY.MM для текущего месяца.
+func (s *Sink) indexName() string {
+	return fmt.Sprintf("%s-%s", s.indexPrefix, time.Now().UTC().Format("2006.01"))
+}
+
+// EnsureIndex создаёt индекс текущего месяца, если его ещё нет, с мэппингом:
+// author — keyword, text/context_* — text, @timestamp — date.
+func (s *Sink) EnsureIndex(ctx context.Context) error {
+	name := s.indexName()
+
+	exists, err := s.client.Indices.Exists([]string{name}, s.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("проверка индекса %s: %w", name, err)
+	}
+	if exists.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	mapping := strings.NewReader(`{
+  "mappings": {
+    "properties": {
+      "text":           {"type": "text"},
+      "author":         {"type": "keyword"},
+      "context_before": {"type": "text"},
+      "context_after":  {"type": "text"},
+      "source_file":    {"type": "keyword"},
+      "@timestamp":     {"type": "date"}
+    }
+  }
+}`)
+
+	res, err := s.client.Indices.Create(name, s.client.Indices.Create.WithContext(ctx), s.client.Indices.Create.WithBody(mapping))
+	if err != nil {
+		return fmt.Errorf("создание индекса %s: %w", name, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("создание индекса %s: %s", name, res.String())
+	}
+	return nil
+}
+
+// Submit ставит документ в очередь на индексацию. Не блокирует вызывающего,
+// кроме случая, когда очередь полностью заполнена.
+func (s *Sink) Submit(d Doc) {
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now().UTC()
+	}
+	s.queue <- d
+}
+
+// Close закрывает очередь, дожидается сброса последних батчей всеми
+// воркерами и останавливает Sink.
+func (s *Sink) Close() {
+	close(s.queue)
+	s.wg.Wait()
+}
+
+func (s *Sink) worker() {
+	defer s.wg.Done()
+
+	batch := make([]Doc, 0, s.flushSize)
+	ticker := time.NewTicker(s.flushEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case d, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, d)
+			if len(batch) >= s.flushSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush отправляет накопленный батч через Bulk API.
+func (s *Sink) flush(batch []Doc) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	index := s.indexName()
+	for _, d := range batch {
+		meta, _ := json.Marshal(map[string]any{"index": map[string]any{"_index": index}})
+		buf.Write(meta)
+		buf.WriteByte('\n')
+		doc, _ := json.Marshal(d)
+		buf.Write(doc)
+		buf.WriteByte('\n')
+	}
+
+	res, err := s.client.Bulk(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("⚠️ ошибка bulk-индексации (%d документов): %v", len(batch), err)
+		return
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		log.Printf("⚠️ bulk-индексация вернула ошибку: %s", res.String())
+	}
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// stateSuffix — имя sidecar-файла с прогрессом обработки, рядом с исходным.
+const stateSuffix = ".qcvs-state.json"
+
+// checkpointState — прогресс обработки одного файла: хэш исходника (чтобы не
+// перепутать состояние с другим файлом) и смещения уже обработанных блоков.
+// Позволяет перезапуском пропустить то, что уже попало в CSV/JSONL.
+type checkpointState struct {
+	mu sync.Mutex
+
+	path              string
+	SHA256            string  `json:"sha256"`
+	ProcessedOffsets  []int64 `json:"processed_offsets"`
+	processedSet      map[int64]bool
+	LastSuccessfulEnd int64 `json:"last_successful_byte"`
+}
+
+// loadCheckpoint читает sidecar filePath+stateSuffix. Если его нет или он
+// относится к другому содержимому файла (другой sha256), возвращает пустое
+// состояние — обработка начнётся с нуля.
+func loadCheckpoint(filePath, sha string) (*checkpointState, error) {
+	path := filePath + stateSuffix
+
+	st := &checkpointState{path: path, SHA256: sha, processedSet: map[int64]bool{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var onDisk checkpointState
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return nil, err
+	}
+	if onDisk.SHA256 != sha {
+		// Файл изменился с прошлого запуска — прогресс больше не валиден.
+		return st, nil
+	}
+
+	st.ProcessedOffsets = onDisk.ProcessedOffsets
+	st.LastSuccessfulEnd = onDisk.LastSuccessfulEnd
+	for _, off := range onDisk.ProcessedOffsets {
+		st.processedSet[off] = true
+	}
+	return st, nil
+}
+
+// isProcessed сообщает, был ли блок с данным смещением уже обработан в
+// предыдущем запуске.
+func (s *checkpointState) isProcessed(offset int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.processedSet[offset]
+}
+
+// markProcessed отмечает блок обработанным и сохраняет sidecar на диск.
+func (s *checkpointState) markProcessed(offset, blockEnd int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.processedSet[offset] {
+		return nil
+	}
+	s.processedSet[offset] = true
+	s.ProcessedOffsets = append(s.ProcessedOffsets, offset)
+	if blockEnd > s.LastSuccessfulEnd {
+		s.LastSuccessfulEnd = blockEnd
+	}
+	return s.saveLocked()
+}
+
+// save сериализует состояние в sidecar-файл; используется также при
+// graceful shutdown, чтобы зафиксировать прогресс перед выходом.
+func (s *checkpointState) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+func (s *checkpointState) saveLocked() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Пишем во временный файл и переименовываем — старый sidecar не
+	// повреждается, если процесс упадёт посреди записи.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// hashFile возвращает sha256 содержимого файла в hex-виде.
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiter — токен-бакет по запросам в минуту и по "токенам" текста в минуту,
+// подобранный под квоту Gemini. Оценка числа токенов грубая (длина текста в
+// байтах / 4), но этого достаточно, чтобы не захлёбывать API.
+type limiter struct {
+	requests *rate.Limiter
+	tokens   *rate.Limiter
+}
+
+// maxBlockTokens — оценка estimateTokens для блока размером maxBlockSize. Бакет токенов должен вмещать хотя бы один такой блок,
+// иначе --tpm меньше этого значения сделал бы WaitN неудовлетворимым
+// навсегда: блок проваливался бы с той же ошибкой при каждой попытке и при
+// каждом повторном запуске после чекпоинта.
+const maxBlockTokens = maxBlockSize/4 + 1
+
+// newLimiter создаёт limiter из --rpm/--tpm. Нулевое или отрицательное
+// значение отключает соответствующее ограничение.
+func newLimiter(rpm, tpm int) *limiter {
+	l := &limiter{}
+	if rpm > 0 {
+		l.requests = rate.NewLimiter(rate.Limit(float64(rpm)/60.0), rpm)
+	}
+	if tpm > 0 {
+		burst := tpm
+		if burst < maxBlockTokens {
+			burst = maxBlockTokens
+		}
+		l.tokens = rate.NewLimiter(rate.Limit(float64(tpm)/60.0), burst)
+	}
+	return l
+}
+
+// estimateTokens — грубая оценка числа токенов в тексте блока.
+func estimateTokens(text string) int {
+	n := len(text) / 4
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// wait блокируется, пока лимитер не разрешит отправить ещё один запрос на
+// estimatedTokens токенов, либо пока не сработает ctx.
+func (l *limiter) wait(ctx context.Context, estimatedTokens int) error {
+	if l.requests != nil {
+		if err := l.requests.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.tokens != nil {
+		// Блок длиннее maxBlockSize (длинная последняя строка) может дать
+		// оценку больше ёмкости бакета; WaitN отверг бы её сразу, поэтому
+		// такой запрос ждёт полный бакет.
+		if burst := l.tokens.Burst(); estimatedTokens > burst {
+			estimatedTokens = burst
+		}
+		if err := l.tokens.WaitN(ctx, estimatedTokens); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff — экспоненциальная задержка перед повтором после 429/5xx:
+// 1s, 2s, 4s, 8s, ... с потолком maxBackoff.
+func backoff(attempt int) time.Duration {
+	const maxBackoff = 30 * time.Second
+	d := time.Second << uint(attempt)
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d
+}
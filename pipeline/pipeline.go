@@ -0,0 +1,445 @@
+// Package pipeline прогоняет текстовый файл через LLM пулом воркеров,
+// ограниченным по скорости, с чекпоинтом на диске — повторный запуск после
+// падения или Ctrl+C пропускает уже обработанные блоки вместо того, чтобы
+// начинать книгу заново.
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EF620/qCVS/dialect"
+	"github.com/EF620/qCVS/essink"
+	"github.com/EF620/qCVS/providers"
+	"github.com/EF620/qCVS/segmenter"
+)
+
+// Значения Config.OutputFormat.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatBoth  = "both"
+)
+
+// ParseOutputFormat проверяет значение флага --output-format.
+func ParseOutputFormat(name string) (string, error) {
+	switch name {
+	case FormatCSV, FormatJSONL, FormatBoth:
+		return name, nil
+	default:
+		return "", fmt.Errorf("неизвестный output-format %q (доступны: csv, jsonl, both)", name)
+	}
+}
+
+// Quote — структура одной цитаты. Поля SourceFile/Offset/ExtractedAt
+// попадают только в JSONL-вывод (SourceFile и ExtractedAt также уходят в
+// ES) — CSV по-прежнему хранит четыре исходные колонки.
+type Quote struct {
+	Text          string    `json:"text"`
+	Author        string    `json:"author"`
+	ContextBefore string    `json:"context_before"`
+	ContextAfter  string    `json:"context_after"`
+	SourceFile    string    `json:"source_file"`
+	Offset        int64     `json:"offset"`
+	ExtractedAt   time.Time `json:"extracted_at"`
+}
+
+// Config — параметры одного прогона обработки файла.
+type Config struct {
+	FilePath  string
+	Author    string
+	Extractor providers.QuoteExtractor
+
+	OutputFormat string
+	CSVDialect   dialect.Dialect
+	Sink         *essink.Sink
+
+	Concurrency   int
+	RPM           int
+	TPM           int
+	MinSimilarity float64
+}
+
+// maxBlockSize — порог размера (в байтах) блока текста, отправляемого в LLM
+// за один раз: блок закрывается на первой границе строки после того, как
+// превысил порог, поэтому он бывает длиннее на длину последней строки.
+const maxBlockSize = 3000
+
+// block — самостоятельный кусок текста с его позицией в исходном файле;
+// Offset используется и как ключ чекпоинта, и как значение Quote.Offset.
+type block struct {
+	Offset int64
+	End    int64
+	Text   string
+	// SentenceStart/SentenceEnd — диапазон индексов в глобальном списке
+	// предложений документа, покрываемый этим блоком. Используется, чтобы
+	// ограничить FuzzyFindNear окрестностью блока, а не всем документом.
+	SentenceStart int
+	SentenceEnd   int
+}
+
+// fuzzyFindMargin — на сколько предложений за границы блока расширяется
+// окно поиска в FuzzyFindNear, чтобы ловить цитаты, попавшие в контекст на
+// стыке двух блоков.
+const fuzzyFindMargin = 50
+
+// Run читает файл, восстанавливает прогресс из sidecar-чекпоинта, и
+// обрабатывает оставшиеся блоки пулом воркеров cfg.Concurrency, уважая
+// отмену ctx (например, по SIGINT) для корректного завершения.
+func Run(ctx context.Context, cfg Config) error {
+	sha, err := hashFile(cfg.FilePath)
+	if err != nil {
+		return fmt.Errorf("хэширование файла: %w", err)
+	}
+	state, err := loadCheckpoint(cfg.FilePath, sha)
+	if err != nil {
+		return fmt.Errorf("чтение чекпоинта: %w", err)
+	}
+
+	blocks, sentences, err := splitIntoBlocks(cfg.FilePath)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(cfg.FilePath, ".txt")
+	var csvPath, jsonlPath string
+	if cfg.OutputFormat == FormatCSV || cfg.OutputFormat == FormatBoth {
+		csvPath = base + ".csv"
+		if _, err := os.Stat(csvPath); os.IsNotExist(err) {
+			if err := initCSV(csvPath, cfg.CSVDialect); err != nil {
+				return err
+			}
+		}
+	}
+	if cfg.OutputFormat == FormatJSONL || cfg.OutputFormat == FormatBoth {
+		jsonlPath = base + ".jsonl"
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	lim := newLimiter(cfg.RPM, cfg.TPM)
+
+	pending := make(chan block)
+	go func() {
+		defer close(pending)
+		for _, b := range blocks {
+			if state.isProcessed(b.Offset) {
+				continue
+			}
+			select {
+			case pending <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	writeMu := make(chanMutex, 1)
+	writeMu <- struct{}{}
+
+	savedCount := 0
+	counted := make(chan int, len(blocks))
+
+	workers := concurrency
+	done := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for b := range pending {
+				n, err := processBlock(ctx, cfg, lim, b, sentences, csvPath, jsonlPath, writeMu)
+				if err != nil {
+					if ctx.Err() != nil {
+						done <- nil
+						return
+					}
+					log.Printf("Ошибка обработки блока со смещением %d: %v", b.Offset, err)
+					continue
+				}
+				if err := state.markProcessed(b.Offset, b.End); err != nil {
+					log.Printf("Ошибка сохранения чекпоинта: %v", err)
+				}
+				counted <- n
+			}
+			done <- nil
+		}()
+	}
+
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+	close(counted)
+	for n := range counted {
+		savedCount += n
+	}
+
+	if err := state.save(); err != nil {
+		log.Printf("Ошибка финального сохранения чекпоинта: %v", err)
+	}
+
+	log.Printf("✅ Всего сохранено цитат за этот запуск: %d", savedCount)
+	return nil
+}
+
+// chanMutex — мьютекс на канале с буфером 1: занять — прочитать токен,
+// освободить — вернуть токен. Используется вместо sync.Mutex, чтобы захват
+// можно было прервать через select на ctx.Done() там, где это нужно.
+type chanMutex chan struct{}
+
+func (m chanMutex) lock()   { <-m }
+func (m chanMutex) unlock() { m <- struct{}{} }
+
+// processBlock прогоняет один блок через LLM (с ретраями и лимитером),
+// проверяет цитаты сегментатором и дописывает подтверждённые в CSV/JSONL/ES.
+// Возвращает число сохранённых цитат.
+func processBlock(ctx context.Context, cfg Config, lim *limiter, b block, sentences []string, csvPath, jsonlPath string, writeMu chanMutex) (int, error) {
+	log.Printf("⚙️ Обработка блока со смещением %d (%d символов)...", b.Offset, len(b.Text))
+
+	aiQuotes, err := extractQuotesWithRetry(ctx, cfg.Extractor, b.Text, lim)
+	if err != nil {
+		return 0, err
+	}
+	if len(aiQuotes) == 0 {
+		return 0, nil
+	}
+
+	searchFrom := b.SentenceStart - fuzzyFindMargin
+	searchTo := b.SentenceEnd + fuzzyFindMargin
+
+	validQuotes := make([]Quote, 0, len(aiQuotes))
+	for _, q := range aiQuotes {
+		m, ok := segmenter.FuzzyFindNear(sentences, searchFrom, searchTo, q, cfg.MinSimilarity)
+		if !ok {
+			continue
+		}
+		start := m.SentenceIndex - 2
+		if start < 0 {
+			start = 0
+		}
+		end := m.SentenceIndex + 3
+		if end > len(sentences) {
+			end = len(sentences)
+		}
+		validQuotes = append(validQuotes, Quote{
+			Text:          q,
+			Author:        cfg.Author,
+			ContextBefore: strings.Join(sentences[start:m.SentenceIndex], " "),
+			ContextAfter:  strings.Join(sentences[m.SentenceIndex+1:end], " "),
+			SourceFile:    cfg.FilePath,
+			Offset:        b.Offset,
+			ExtractedAt:   time.Now().UTC(),
+		})
+	}
+
+	if len(validQuotes) == 0 {
+		return 0, nil
+	}
+
+	// writeMu сериализует только запись в CSV/JSONL-файлы этого файла-источника.
+	// cfg.Sink.Submit — канал с собственной синхронизацией для конкурентных
+	// отправителей, поэтому он вызывается уже после unlock: иначе воркер,
+	// застрявший на отправке в забитый (или недоступный) ES-синк, держит
+	// writeMu и блокирует запись CSV/JSONL всех остальных воркеров.
+	writeMu.lock()
+	writeErr := writeValidQuotes(csvPath, jsonlPath, validQuotes, cfg.CSVDialect)
+	writeMu.unlock()
+	if writeErr != nil {
+		return 0, writeErr
+	}
+
+	if cfg.Sink != nil {
+		for _, q := range validQuotes {
+			cfg.Sink.Submit(essink.Doc{
+				Text:          q.Text,
+				Author:        q.Author,
+				ContextBefore: q.ContextBefore,
+				ContextAfter:  q.ContextAfter,
+				SourceFile:    q.SourceFile,
+				Timestamp:     q.ExtractedAt,
+			})
+		}
+	}
+
+	log.Printf("✅ Сохранено %d цитат (блок %d)", len(validQuotes), b.Offset)
+	return len(validQuotes), nil
+}
+
+// writeValidQuotes дописывает quotes в csvPath и/или jsonlPath (любой путь
+// может быть пустым). Вызывается под writeMu — единственная часть
+// processBlock, которую действительно нужно сериализовать между воркерами.
+func writeValidQuotes(csvPath, jsonlPath string, quotes []Quote, d dialect.Dialect) error {
+	if csvPath != "" {
+		if err := appendToCSV(csvPath, quotes, d); err != nil {
+			return fmt.Errorf("запись CSV: %w", err)
+		}
+	}
+	if jsonlPath != "" {
+		if err := appendToJSONL(jsonlPath, quotes); err != nil {
+			return fmt.Errorf("запись JSONL: %w", err)
+		}
+	}
+	return nil
+}
+
+// splitIntoBlocks читает файл целиком, нарезая его на блоки по maxBlockSize
+// байт (как раньше делал processFile), и параллельно строит список
+// предложений всего документа для последующего нечёткого поиска цитат.
+func splitIntoBlocks(filePath string) ([]block, []string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	seg := segmenter.New("ru")
+
+	var blocks []block
+	var sentences []string
+	var buf strings.Builder
+	var offset, blockStart int64
+	sentenceStart := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		sentences = append(sentences, seg.Split(line)...)
+		buf.WriteString(line + "\n")
+		offset += int64(len(line)) + 1
+		if buf.Len() > maxBlockSize {
+			blocks = append(blocks, block{
+				Offset:        blockStart,
+				End:           offset,
+				Text:          buf.String(),
+				SentenceStart: sentenceStart,
+				SentenceEnd:   len(sentences),
+			})
+			buf.Reset()
+			blockStart = offset
+			sentenceStart = len(sentences)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if buf.Len() > 0 {
+		blocks = append(blocks, block{
+			Offset:        blockStart,
+			End:           offset,
+			Text:          buf.String(),
+			SentenceStart: sentenceStart,
+			SentenceEnd:   len(sentences),
+		})
+	}
+
+	return blocks, sentences, nil
+}
+
+// extractQuotesWithRetry вызывает cfg.Extractor.Extract, повторяя запрос с
+// экспоненциальной задержкой при 429/5xx-ошибках провайдера.
+func extractQuotesWithRetry(ctx context.Context, extractor providers.QuoteExtractor, text string, lim *limiter) ([]string, error) {
+	const maxAttempts = 5
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err := lim.wait(ctx, estimateTokens(text)); err != nil {
+			return nil, err
+		}
+
+		quotes, err := extractor.Extract(ctx, text)
+		if err == nil {
+			return quotes, nil
+		}
+		lastErr = err
+		if !isRetryable(err) {
+			return nil, err
+		}
+
+		log.Printf("⚠️ Повторяемая ошибка провайдера (попытка %d/%d): %v", attempt+1, maxAttempts, err)
+		select {
+		case <-time.After(backoff(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("исчерпаны попытки обращения к провайдеру: %w", lastErr)
+}
+
+// isRetryable сообщает, стоит ли повторить запрос к провайдеру. Ошибки HTTP-
+// и SDK-клиентов не несут единого типизированного статуса, поэтому смотрим
+// на текст.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, marker := range []string{"429", "RESOURCE_EXHAUSTED", "500", "502", "503", "504", "UNAVAILABLE"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// initCSV — создаёт CSV с заголовками в указанном диалекте
+func initCSV(filePath string, d dialect.Dialect) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := d.NewWriter(file)
+	if err != nil {
+		return err
+	}
+	defer writer.Flush()
+
+	return writer.Write([]string{"Цитата", "Автор", "Контекст (До)", "Контекст (После)"})
+}
+
+// appendToCSV — добавляет записи в CSV в указанном диалекте
+func appendToCSV(filePath string, quotes []Quote, d dialect.Dialect) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = d.Comma
+	writer.UseCRLF = d.UseCRLF
+	defer writer.Flush()
+
+	for _, q := range quotes {
+		if err := writer.Write([]string{q.Text, q.Author, q.ContextBefore, q.ContextAfter}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// appendToJSONL — дописывает по одному JSON-объекту на строку (NDJSON)
+func appendToJSONL(filePath string, quotes []Quote) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	for _, q := range quotes {
+		if err := enc.Encode(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
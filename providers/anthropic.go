@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// anthropicExtractor — провайдер поверх Anthropic Messages API.
+type anthropicExtractor struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	prompt     *template.Template
+}
+
+func newAnthropicExtractor(cfg Config) (*anthropicExtractor, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY не задан")
+	}
+
+	baseURL := os.Getenv("ANTHROPIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = "claude-3-5-sonnet-latest"
+	}
+
+	return &anthropicExtractor{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		model:      model,
+		prompt:     cfg.Prompt,
+	}, nil
+}
+
+type anthropicMessagesRequest struct {
+	Model     string        `json:"model"`
+	MaxTokens int           `json:"max_tokens"`
+	Messages  []chatMessage `json:"messages"`
+}
+
+type anthropicMessagesResponse struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func (a *anthropicExtractor) Extract(ctx context.Context, text string) ([]string, error) {
+	prompt, err := renderPrompt(a.prompt, text)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(anthropicMessagesRequest{
+		Model:     a.model,
+		MaxTokens: 2048,
+		Messages:  []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Anthropic API вернул %d: %s", res.StatusCode, respBody)
+	}
+
+	var msgResp anthropicMessagesResponse
+	if err := json.Unmarshal(respBody, &msgResp); err != nil {
+		return nil, fmt.Errorf("разбор ответа Anthropic: %w", err)
+	}
+	if len(msgResp.Content) == 0 {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+	for _, block := range msgResp.Content {
+		sb.WriteString(block.Text)
+	}
+	return parseQuotesJSON(sb.String())
+}
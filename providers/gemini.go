@@ -0,0 +1,52 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/template"
+
+	"google.golang.org/genai"
+)
+
+// geminiExtractor — исходный провайдер, теперь запрашивающий у Gemini
+// структурированный вывод (response_mime_type=application/json), так что
+// парсинг больше не зависит от удаления ```json на нашей стороне.
+type geminiExtractor struct {
+	client *genai.Client
+	model  string
+	prompt *template.Template
+}
+
+func newGeminiExtractor(cfg Config) (*geminiExtractor, error) {
+	if os.Getenv("GOOGLE_API_KEY") == "" {
+		return nil, fmt.Errorf("GOOGLE_API_KEY не задан")
+	}
+
+	client, err := genai.NewClient(context.Background(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("создание клиента Gemini: %w", err)
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = "gemini-2.5-flash"
+	}
+
+	return &geminiExtractor{client: client, model: model, prompt: cfg.Prompt}, nil
+}
+
+func (g *geminiExtractor) Extract(ctx context.Context, text string) ([]string, error) {
+	prompt, err := renderPrompt(g.prompt, text)
+	if err != nil {
+		return nil, err
+	}
+
+	genCfg := &genai.GenerateContentConfig{ResponseMIMEType: "application/json"}
+	resp, err := g.client.Models.GenerateContent(ctx, g.model, genai.Text(prompt), genCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseQuotesJSON(resp.Text())
+}
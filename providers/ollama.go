@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+)
+
+// ollamaExtractor — провайдер для локального Ollama (/api/generate).
+type ollamaExtractor struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	prompt     *template.Template
+}
+
+func newOllamaExtractor(cfg Config) (*ollamaExtractor, error) {
+	baseURL := os.Getenv("OLLAMA_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = "llama3"
+	}
+
+	return &ollamaExtractor{
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+		baseURL:    baseURL,
+		model:      model,
+		prompt:     cfg.Prompt,
+	}, nil
+}
+
+type ollamaGenerateRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Format string `json:"format"`
+	Stream bool   `json:"stream"`
+}
+
+type ollamaGenerateResponse struct {
+	Response string `json:"response"`
+}
+
+func (o *ollamaExtractor) Extract(ctx context.Context, text string) ([]string, error) {
+	prompt, err := renderPrompt(o.prompt, text)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(ollamaGenerateRequest{
+		Model:  o.model,
+		Prompt: prompt,
+		Format: "json",
+		Stream: false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/generate", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama вернул %d: %s", res.StatusCode, respBody)
+	}
+
+	var genResp ollamaGenerateResponse
+	if err := json.Unmarshal(respBody, &genResp); err != nil {
+		return nil, fmt.Errorf("разбор ответа Ollama: %w", err)
+	}
+
+	return parseQuotesJSON(genResp.Response)
+}
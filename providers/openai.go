@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+)
+
+// chatMessage — сообщение в формате OpenAI/Anthropic chat-эндпоинтов.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIExtractor — провайдер для OpenAI и OpenAI-совместимых шлюзов
+// (тот же /chat/completions формат).
+type openAIExtractor struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	prompt     *template.Template
+}
+
+func newOpenAIExtractor(cfg Config) (*openAIExtractor, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY не задан")
+	}
+
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	return &openAIExtractor{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		model:      model,
+		prompt:     cfg.Prompt,
+	}, nil
+}
+
+type openAIChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (o *openAIExtractor) Extract(ctx context.Context, text string) ([]string, error) {
+	prompt, err := renderPrompt(o.prompt, text)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(openAIChatRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI API вернул %d: %s", res.StatusCode, respBody)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return nil, fmt.Errorf("разбор ответа OpenAI: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, nil
+	}
+
+	return parseQuotesJSON(chatResp.Choices[0].Message.Content)
+}
@@ -0,0 +1,113 @@
+// Package providers абстрагирует вызов LLM для извлечения цитат за
+// интерфейсом QuoteExtractor, чтобы экстрактор мог работать с Gemini,
+// OpenAI-совместимыми API, Anthropic или локальным Ollama без перекомпиляции
+// под конкретного вендора.
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Имена провайдеров, принимаемые флагом --provider.
+const (
+	Gemini    = "gemini"
+	OpenAI    = "openai"
+	Anthropic = "anthropic"
+	Ollama    = "ollama"
+)
+
+// QuoteExtractor — любой бэкенд, умеющий вытащить цитаты из куска текста.
+type QuoteExtractor interface {
+	Extract(ctx context.Context, text string) ([]string, error)
+}
+
+// Config — параметры создания QuoteExtractor.
+type Config struct {
+	Provider string
+	Model    string
+	// Prompt — шаблон text/template с полем .Text; при nil New подставляет
+	// DefaultPromptTemplate.
+	Prompt *template.Template
+}
+
+// DefaultPromptTemplate — запрос на извлечение цитат, использовавшийся до
+// появления --prompt-file.
+const DefaultPromptTemplate = `Извлеки из текста 3-10 ярких, выразительных цитат.
+Ответ верни строго в формате JSON массива строк. Пример: ["цитата1", "цитата2"].
+Не добавляй лишних символов, обратных кавычек или пояснений.
+
+Текст:
+{{.Text}}`
+
+// LoadPromptTemplate парсит шаблон из promptFile (флаг --prompt-file); при
+// пустом promptFile возвращает DefaultPromptTemplate. Шаблон должен
+// использовать поле .Text для вставки анализируемого текста.
+func LoadPromptTemplate(promptFile string) (*template.Template, error) {
+	if promptFile == "" {
+		return template.New("prompt").Parse(DefaultPromptTemplate)
+	}
+	data, err := os.ReadFile(promptFile)
+	if err != nil {
+		return nil, fmt.Errorf("чтение --prompt-file: %w", err)
+	}
+	return template.New("prompt").Parse(string(data))
+}
+
+// renderPrompt подставляет text в шаблон.
+func renderPrompt(tmpl *template.Template, text string) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, struct{ Text string }{Text: text}); err != nil {
+		return "", fmt.Errorf("рендеринг шаблона промпта: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// New создаёт QuoteExtractor для cfg.Provider, читая нужные провайдеру
+// переменные окружения.
+func New(cfg Config) (QuoteExtractor, error) {
+	if cfg.Prompt == nil {
+		tmpl, err := LoadPromptTemplate("")
+		if err != nil {
+			return nil, err
+		}
+		cfg.Prompt = tmpl
+	}
+
+	switch cfg.Provider {
+	case Gemini:
+		return newGeminiExtractor(cfg)
+	case OpenAI:
+		return newOpenAIExtractor(cfg)
+	case Anthropic:
+		return newAnthropicExtractor(cfg)
+	case Ollama:
+		return newOllamaExtractor(cfg)
+	default:
+		return nil, fmt.Errorf("неизвестный --provider %q (доступны: gemini, openai, anthropic, ollama)", cfg.Provider)
+	}
+}
+
+// parseQuotesJSON разбирает ответ модели как JSON-массив строк. Защитный
+// запасной вариант на случай, если провайдер (обычно без структурированного
+// вывода — Ollama, старые OpenAI-совместимые API) всё же обернул ответ в
+// ```json на своей стороне.
+func parseQuotesJSON(raw string) ([]string, error) {
+	response := strings.TrimSpace(raw)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+	response = strings.TrimSpace(response)
+
+	var quotes []string
+	if err := json.Unmarshal([]byte(response), &quotes); err != nil {
+		log.Printf("⚠️ Ответ не JSON, пропускаю блок. Ответ: %s", response)
+		return nil, nil
+	}
+	return quotes, nil
+}
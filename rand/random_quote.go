@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"flag"
@@ -12,35 +13,81 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/EF620/qCVS/dialect"
 )
 
-// Quote — структура для хранения цитаты из CSV
+// Quote — структура для хранения цитаты, прочитанной из CSV или JSONL
 type Quote struct {
-	Text          string
-	Author        string
-	ContextBefore string
-	ContextAfter  string
+	Text          string `json:"text"`
+	Author        string `json:"author"`
+	ContextBefore string `json:"context_before"`
+	ContextAfter  string `json:"context_after"`
 }
 
-// findCSVFiles — рекурсивно ищет все CSV-файлы в папке и подпапках
-func findCSVFiles(rootDir string) ([]string, error) {
-	var csvFiles []string
+// findQuoteFiles — рекурсивно ищет все CSV- и JSONL-файлы в папке и подпапках
+func findQuoteFiles(rootDir string) ([]string, error) {
+	var files []string
 	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".csv") {
-			csvFiles = append(csvFiles, path)
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(d.Name())) {
+		case ".csv", ".jsonl":
+			files = append(files, path)
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при поиске CSV-файлов: %v", err)
+		return nil, fmt.Errorf("ошибка при поиске файлов с цитатами: %v", err)
 	}
-	return csvFiles, nil
+	return files, nil
 }
 
-// readQuotesFromCSV — читает все цитаты из одного CSV-файла
+// readQuotesFromFile — читает цитаты из файла, выбирая парсер по расширению
+// (.csv или .jsonl), так что random-quote работает с любым из форматов,
+// которые умеет писать экстрактор.
+func readQuotesFromFile(filePath string) ([]Quote, error) {
+	if strings.ToLower(filepath.Ext(filePath)) == ".jsonl" {
+		return readQuotesFromJSONL(filePath)
+	}
+	return readQuotesFromCSV(filePath)
+}
+
+// readQuotesFromJSONL — читает цитаты из NDJSON-файла (по одному объекту на строку)
+func readQuotesFromJSONL(filePath string) ([]Quote, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при открытии файла %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	var quotes []Quote
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var q Quote
+		if err := json.Unmarshal([]byte(line), &q); err != nil {
+			log.Printf("Предупреждение: строка в файле %s не является корректным JSON: %v", filePath, err)
+			continue
+		}
+		quotes = append(quotes, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении JSONL %s: %v", filePath, err)
+	}
+
+	return quotes, nil
+}
+
+// readQuotesFromCSV — читает все цитаты из одного CSV-файла, автоматически
+// определяя диалект (BOM и разделитель), в котором он был записан.
 func readQuotesFromCSV(filePath string) ([]Quote, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -48,8 +95,14 @@ func readQuotesFromCSV(filePath string) ([]Quote, error) {
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = ';' // Устанавливаем разделитель ;
+	bufr := bufio.NewReader(file)
+	d, err := dialect.Detect(bufr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при определении диалекта %s: %v", filePath, err)
+	}
+
+	reader := csv.NewReader(bufr)
+	reader.Comma = d.Comma
 	reader.TrimLeadingSpace = true
 
 	// Читаем все записи
@@ -58,6 +111,10 @@ func readQuotesFromCSV(filePath string) ([]Quote, error) {
 		return nil, fmt.Errorf("ошибка при чтении CSV %s: %v", filePath, err)
 	}
 
+	if len(records) == 0 {
+		return nil, nil
+	}
+
 	var quotes []Quote
 	// Пропускаем заголовок (первая строка)
 	for i, record := range records[1:] {
@@ -76,21 +133,21 @@ func readQuotesFromCSV(filePath string) ([]Quote, error) {
 	return quotes, nil
 }
 
-// getRandomQuote — возвращает случайную цитату из всех CSV-файлов
+// getRandomQuote — возвращает случайную цитату из всех CSV- и JSONL-файлов
 func getRandomQuote(rootDir string) (Quote, error) {
-	// Находим все CSV-файлы
-	csvFiles, err := findCSVFiles(rootDir)
+	// Находим все файлы с цитатами
+	quoteFiles, err := findQuoteFiles(rootDir)
 	if err != nil {
 		return Quote{}, err
 	}
-	if len(csvFiles) == 0 {
-		return Quote{}, fmt.Errorf("в папке %s не найдено CSV-файлов", rootDir)
+	if len(quoteFiles) == 0 {
+		return Quote{}, fmt.Errorf("в папке %s не найдено CSV- или JSONL-файлов", rootDir)
 	}
 
 	// Собираем все цитаты из всех файлов
 	var allQuotes []Quote
-	for _, filePath := range csvFiles {
-		quotes, err := readQuotesFromCSV(filePath)
+	for _, filePath := range quoteFiles {
+		quotes, err := readQuotesFromFile(filePath)
 		if err != nil {
 			log.Printf("Ошибка при чтении файла %s: %v", filePath, err)
 			continue
@@ -99,7 +156,7 @@ func getRandomQuote(rootDir string) (Quote, error) {
 	}
 
 	if len(allQuotes) == 0 {
-		return Quote{}, fmt.Errorf("не найдено цитат в CSV-файлах")
+		return Quote{}, fmt.Errorf("не найдено цитат в найденных файлах")
 	}
 
 	// Выбираем случайную цитату
@@ -0,0 +1,235 @@
+// Package segmenter разбивает текст на предложения с учётом сокращений и
+// инициалов конкретного языка, и умеет находить предложение, в которое
+// слегка перефразированная LLM цитата попадает по нормализованному нечёткому
+// совпадению, а не по точному strings.Contains.
+package segmenter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// initialsPattern — инициалы вида "А.", "А.С.", "J.R.R." (1-3 заглавные буквы
+// через точку без пробелов), которые не завершают предложение.
+var initialsPattern = regexp.MustCompile(`^\p{Lu}\.(\p{Lu}\.){0,2}$`)
+
+// ruAbbreviations — частые русские сокращения, после которых точка не
+// завершает предложение. Хранятся уже с завершающей точкой — именно так они
+// сравниваются с последним "словом" накопленного предложения.
+var ruAbbreviations = map[string]bool{
+	"т.е.": true, "т.к.": true, "т.п.": true, "т.д.": true,
+	"др.": true, "пр.": true, "см.": true, "гл.": true,
+	"им.": true, "г.": true, "гг.": true, "в.": true, "вв.": true,
+	"ул.": true, "стр.": true, "обл.": true,
+}
+
+// Segmenter разбивает текст на предложения для конкретного языка.
+type Segmenter struct {
+	abbreviations map[string]bool
+}
+
+// New создаёт Segmenter со списком сокращений для lang. Сейчас поддерживается
+// "ru"; для любого другого значения используются только инициалы и скобки.
+func New(lang string) *Segmenter {
+	switch lang {
+	case "ru":
+		return &Segmenter{abbreviations: ruAbbreviations}
+	default:
+		return &Segmenter{abbreviations: map[string]bool{}}
+	}
+}
+
+// Split разбивает text на предложения, не обрывая их на сокращениях
+// ("т.е.", "т.к.", инициалах вида "А.С. Пушкин"), внутри скобок/кавычек «...»
+// и на многоточии "…".
+func (s *Segmenter) Split(text string) []string {
+	var sentences []string
+	var sb strings.Builder
+	parenLevel := 0
+
+	runes := []rune(text)
+	for i, r := range runes {
+		sb.WriteRune(r)
+
+		switch r {
+		case '(', '[', '{', '«':
+			parenLevel++
+		case ')', ']', '}', '»':
+			parenLevel--
+		}
+
+		isTerminator := r == '.' || r == '!' || r == '?' || r == '…'
+		if !isTerminator || parenLevel > 0 {
+			continue
+		}
+
+		// Не конец предложения, если за терминатором сразу идёт не-пробельный
+		// символ (например, внутри "т.е." или "3.14").
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+
+		if r == '.' && s.isAbbreviation(lastWord(sb.String())) {
+			continue
+		}
+
+		if sentence := strings.TrimSpace(sb.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		sb.Reset()
+	}
+
+	if sentence := strings.TrimSpace(sb.String()); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+
+	return sentences
+}
+
+// lastWord возвращает последний непрерывный (без пробелов) токен строки.
+func lastWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// isAbbreviation сообщает, что word (вместе с завершающей точкой) — известное
+// сокращение или инициалы, после которых точка не завершает предложение.
+func (s *Segmenter) isAbbreviation(word string) bool {
+	if word == "" {
+		return false
+	}
+	if s.abbreviations[strings.ToLower(word)] {
+		return true
+	}
+	return initialsPattern.MatchString(word)
+}
+
+// Match — результат нечёткого поиска цитаты среди предложений.
+type Match struct {
+	SentenceIndex int
+	Similarity    float64
+}
+
+// windowSizes — сколько подряд идущих предложений пробуем склеивать при
+// поиске, чтобы ловить цитаты, которые LLM процитировала на стыке двух фраз.
+var windowSizes = []int{1, 2}
+
+// FuzzyFind ищет sentences, нормализованное содержимое которых ближе всего к
+// query (NFKC-фолдинг + нижний регистр + только буквы/цифры), и возвращает
+// индекс первого предложения окна с наилучшим совпадением, если его схожесть
+// не ниже minSimilarity. Сравнивает со всем документом — для больших файлов
+// предпочитайте FuzzyFindNear, ограничивающий поиск окрестностью блока.
+func FuzzyFind(sentences []string, query string, minSimilarity float64) (Match, bool) {
+	return FuzzyFindNear(sentences, 0, len(sentences), query, minSimilarity)
+}
+
+// FuzzyFindNear — то же, что FuzzyFind, но сравнивает query только с окнами,
+// целиком лежащими в [from, to) (границы обрезаются по длине sentences). Цитата,
+// которую извлекла LLM, всегда находится рядом со своим исходным блоком, так
+// что сканирование всего документа — от первых страниц до последних — на
+// каждую цитату каждого блока не нужно и на больших книгах начинает
+// доминировать над временем всего пайплайна.
+func FuzzyFindNear(sentences []string, from, to int, query string, minSimilarity float64) (Match, bool) {
+	normQuery := normalize(query)
+	if normQuery == "" {
+		return Match{}, false
+	}
+
+	if from < 0 {
+		from = 0
+	}
+	if to > len(sentences) {
+		to = len(sentences)
+	}
+
+	best := Match{SentenceIndex: -1}
+	for _, size := range windowSizes {
+		end := to - size
+		for i := from; i <= end; i++ {
+			window := strings.Join(sentences[i:i+size], " ")
+			sim := ratio(normalize(window), normQuery)
+			if sim > best.Similarity {
+				best = Match{SentenceIndex: i, Similarity: sim}
+			}
+		}
+	}
+
+	if best.SentenceIndex < 0 || best.Similarity < minSimilarity {
+		return Match{}, false
+	}
+	return best, true
+}
+
+// normalize приводит строку к виду, пригодному для сравнения: NFKC-фолдинг,
+// нижний регистр, оставлены только буквы и цифры.
+func normalize(s string) string {
+	s = norm.NFKC.String(s)
+	s = strings.ToLower(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ratio — коэффициент схожести Ratcliff/Obershelp в диапазоне [0, 1]:
+// удвоенное число совпавших символов, делённое на суммарную длину строк.
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	matched := matchingChars(ra, rb)
+	return 2 * float64(matched) / float64(len(ra)+len(rb))
+}
+
+// matchingChars рекурсивно суммирует длины совпадающих подстрок a и b, как в
+// алгоритме Ratcliff/Obershelp.
+func matchingChars(a, b []rune) int {
+	aStart, bStart, length := longestCommonSubstring(a, b)
+	if length == 0 {
+		return 0
+	}
+	total := length
+	total += matchingChars(a[:aStart], b[:bStart])
+	total += matchingChars(a[aStart+length:], b[bStart+length:])
+	return total
+}
+
+// longestCommonSubstring находит самую длинную общую подстроку a и b через
+// динамическое программирование O(len(a)*len(b)).
+func longestCommonSubstring(a, b []rune) (aStart, bStart, length int) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, 0
+	}
+
+	prev := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > length {
+					length = curr[j]
+					aStart = i - length
+					bStart = j - length
+				}
+			}
+		}
+		prev = curr
+	}
+	return aStart, bStart, length
+}
@@ -0,0 +1,109 @@
+package segmenter
+
+import "testing"
+
+func TestSplit_Abbreviations(t *testing.T) {
+	s := New("ru")
+
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "т.е. не разрывает предложение",
+			text: "Он опоздал, т.е. пришёл позже всех. Никто не удивился.",
+			want: []string{
+				"Он опоздал, т.е. пришёл позже всех.",
+				"Никто не удивился.",
+			},
+		},
+		{
+			name: "инициалы не разрывают предложение",
+			text: "А.С. Пушкин написал «Евгения Онегина». Это классика.",
+			want: []string{
+				"А.С. Пушкин написал «Евгения Онегина».",
+				"Это классика.",
+			},
+		},
+		{
+			name: "многоточие завершает предложение",
+			text: "Он замолчал… Все ждали ответа.",
+			want: []string{
+				"Он замолчал…",
+				"Все ждали ответа.",
+			},
+		},
+		{
+			name: "точка внутри кавычек-ёлочек не разрывает предложение",
+			text: "Она сказала: «Я приду в 9 ч. вечера». Все согласились.",
+			want: []string{
+				"Она сказала: «Я приду в 9 ч. вечера».",
+				"Все согласились.",
+			},
+		},
+		{
+			name: "т.к. не разрывает предложение",
+			text: "Поезд задержали, т.к. шёл снег. Пассажиры ждали на платформе.",
+			want: []string{
+				"Поезд задержали, т.к. шёл снег.",
+				"Пассажиры ждали на платформе.",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := s.Split(tc.text)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Split(%q) = %d предложений %v, хотим %d: %v", tc.text, len(got), got, len(tc.want), tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("предложение %d: получили %q, хотим %q", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFuzzyFind(t *testing.T) {
+	sentences := []string{
+		"Он опоздал, т.е. пришёл позже всех.",
+		"Никто не удивился.",
+		"Поезд задержали, т.к. шёл снег.",
+	}
+
+	m, ok := FuzzyFind(sentences, "он опоздал то есть пришел позже всех", 0.7)
+	if !ok {
+		t.Fatal("FuzzyFind не нашёл совпадение для перефразированной цитаты")
+	}
+	if m.SentenceIndex != 0 {
+		t.Errorf("SentenceIndex = %d, хотим 0", m.SentenceIndex)
+	}
+
+	if _, ok := FuzzyFind(sentences, "на улице шёл дождь и было холодно", 0.9); ok {
+		t.Error("FuzzyFind нашёл совпадение там, где его быть не должно")
+	}
+}
+
+func TestFuzzyFindNear_BoundsSearch(t *testing.T) {
+	sentences := []string{
+		"Он опоздал, т.е. пришёл позже всех.",
+		"Никто не удивился.",
+		"Поезд задержали, т.к. шёл снег.",
+	}
+
+	// Цитата из предложения 0 не найдётся, если окно поиска её не покрывает.
+	if _, ok := FuzzyFindNear(sentences, 1, 3, "он опоздал то есть пришел позже всех", 0.7); ok {
+		t.Error("FuzzyFindNear нашёл совпадение за пределами [from, to)")
+	}
+
+	m, ok := FuzzyFindNear(sentences, 0, 1, "он опоздал то есть пришел позже всех", 0.7)
+	if !ok {
+		t.Fatal("FuzzyFindNear не нашёл совпадение внутри заданного окна")
+	}
+	if m.SentenceIndex != 0 {
+		t.Errorf("SentenceIndex = %d, хотим 0", m.SentenceIndex)
+	}
+}